@@ -6,8 +6,8 @@ package tango
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"encoding/xml"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -16,6 +16,9 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 type Handler interface {
@@ -37,9 +40,27 @@ type Context struct {
 
 	action interface{}
 	Result interface{}
+
+	stdCtx    context.Context
+	cancel    context.CancelFunc
+	streaming bool
+}
+
+// DefaultTimeout bounds how long a request's Context() may run before it is
+// canceled. Zero means no timeout. A matched action can override it for its
+// own route by implementing TimeoutHandler.
+var DefaultTimeout time.Duration
+
+// TimeoutHandler is implemented by route actions that want a request
+// timeout other than DefaultTimeout.
+type TimeoutHandler interface {
+	Timeout() time.Duration
 }
 
 func (ctx *Context) reset(req *http.Request, resp ResponseWriter) {
+	if ctx.cancel != nil {
+		ctx.cancel()
+	}
 	ctx.req = req
 	ctx.ResponseWriter = resp
 	ctx.idx = 0
@@ -50,6 +71,9 @@ func (ctx *Context) reset(req *http.Request, resp ResponseWriter) {
 	ctx.matched = false
 	ctx.action = nil
 	ctx.Result = nil
+	ctx.stdCtx = nil
+	ctx.cancel = nil
+	ctx.streaming = false
 }
 
 func (ctx *Context) HandleError() {
@@ -90,6 +114,12 @@ func (ctx *Context) Params() *Params {
 	return &ctx.params
 }
 
+// URL builds the path for the named route; see Tango.URL. Templates and
+// redirect handlers should use it instead of hard-coding paths.
+func (ctx *Context) URL(name string, pairs ...interface{}) string {
+	return ctx.tan.URL(name, pairs...)
+}
+
 func (ctx *Context) IP() string {
 	proxy := []string{}
 	if ips := ctx.Req().Header.Get("X-Forwarded-For"); ips != "" {
@@ -135,6 +165,23 @@ func (ctx *Context) newAction() {
 				ctx.callArgs = []reflect.Value{reflect.ValueOf(ctx.ResponseWriter)}
 			case FuncCtxRoute:
 				ctx.callArgs = []reflect.Value{reflect.ValueOf(ctx)}
+			case FuncStdCtxRoute:
+				// Build the arg from ensureStdCtx/stdCtx directly: calling
+				// the public Context() here would re-enter newAction()
+				// before ctx.matched is set, re-matching the route and
+				// recursing forever.
+				ctx.ensureStdCtx()
+				ctx.callArgs = []reflect.Value{reflect.ValueOf(ctx.stdCtx)}
+			case FuncWebSocketRoute:
+				// A struct method handler needs its receiver bound ahead
+				// of the conn/ctx args execute() appends after upgrading;
+				// a plain func(*websocket.Conn, *Context) literal is
+				// called directly in execute() and needs no callArgs.
+				if ctx.route.method.IsValid() {
+					ctx.callArgs = []reflect.Value{vc.Elem()}
+				} else {
+					ctx.callArgs = nil
+				}
 			default:
 				panic("routeType error")
 			}
@@ -143,6 +190,63 @@ func (ctx *Context) newAction() {
 	}
 }
 
+// ensureStdCtx lazily derives ctx.stdCtx from the request's context, bounded
+// by the matched action's TimeoutHandler timeout or DefaultTimeout, and
+// wires it to cancel when the client disconnects.
+func (ctx *Context) ensureStdCtx() {
+	if ctx.stdCtx != nil {
+		return
+	}
+
+	timeout := DefaultTimeout
+	if t, ok := ctx.action.(TimeoutHandler); ok {
+		timeout = t.Timeout()
+	}
+
+	if timeout > 0 {
+		ctx.stdCtx, ctx.cancel = context.WithTimeout(ctx.req.Context(), timeout)
+	} else {
+		ctx.stdCtx, ctx.cancel = context.WithCancel(ctx.req.Context())
+	}
+	ctx.req = ctx.req.WithContext(ctx.stdCtx)
+
+	if cn, ok := ctx.ResponseWriter.(http.CloseNotifier); ok {
+		go func(cancel context.CancelFunc, closed <-chan bool, done <-chan struct{}) {
+			select {
+			case <-closed:
+				cancel()
+			case <-done:
+			}
+		}(ctx.cancel, cn.CloseNotify(), ctx.stdCtx.Done())
+	}
+}
+
+// Context returns a context.Context tied to the request. It is canceled
+// when the client disconnects, when it exceeds its timeout (see
+// TimeoutHandler and DefaultTimeout), or once the action has returned.
+func (ctx *Context) Context() context.Context {
+	ctx.newAction()
+	ctx.ensureStdCtx()
+	return ctx.stdCtx
+}
+
+// WithValue attaches key/val to ctx's context.Context, so later calls to
+// Context() on this request see it.
+func (ctx *Context) WithValue(key, val interface{}) {
+	ctx.newAction()
+	ctx.ensureStdCtx()
+	ctx.stdCtx = context.WithValue(ctx.stdCtx, key, val)
+	ctx.req = ctx.req.WithContext(ctx.stdCtx)
+}
+
+// Deadline reports the deadline of ctx's context.Context, as per
+// context.Context.Deadline.
+func (ctx *Context) Deadline() (time.Time, bool) {
+	ctx.newAction()
+	ctx.ensureStdCtx()
+	return ctx.stdCtx.Deadline()
+}
+
 // WARNING: don't invoke this method on action
 func (ctx *Context) Next() {
 	ctx.idx += 1
@@ -172,7 +276,30 @@ func (ctx *Context) execute() {
 			fn(ctx.req)
 		case func(http.ResponseWriter):
 			fn(ctx.ResponseWriter)
+		case func(context.Context):
+			fn(ctx.Context())
+		case func(*websocket.Conn, *Context):
+			conn, err := ctx.Upgrade()
+			if err != nil {
+				ctx.Result = err
+				break
+			}
+			defer conn.Close()
+			fn(conn, ctx)
 		default:
+			if ctx.route.routeType == FuncWebSocketRoute {
+				// A struct method of the func(*websocket.Conn, *Context)
+				// shape: the func-literal case above didn't match, so do
+				// the upgrade here and append conn/ctx to the receiver
+				// newAction() already put in callArgs.
+				conn, err := ctx.Upgrade()
+				if err != nil {
+					ctx.Result = err
+					break
+				}
+				defer conn.Close()
+				ctx.callArgs = append(ctx.callArgs, reflect.ValueOf(conn), reflect.ValueOf(ctx))
+			}
 			ret = ctx.route.method.Call(ctx.callArgs)
 		}
 
@@ -189,6 +316,10 @@ func (ctx *Context) execute() {
 			ctx.NotFound()
 		}
 	}
+
+	if ctx.cancel != nil {
+		ctx.cancel()
+	}
 }
 
 func (ctx *Context) invoke() {
@@ -213,23 +344,11 @@ func (ctx *Context) ServeFile(path string) error {
 }
 
 func (ctx *Context) ServeXml(obj interface{}) error {
-	encoder := xml.NewEncoder(ctx)
-	ctx.Header().Set("Content-Type", "application/xml; charset=UTF-8")
-	err := encoder.Encode(obj)
-	if err != nil {
-		ctx.Header().Del("Content-Type")
-	}
-	return err
+	return ctx.renderAs("application/xml", obj)
 }
 
 func (ctx *Context) ServeJson(obj interface{}) error {
-	encoder := json.NewEncoder(ctx)
-	ctx.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	err := encoder.Encode(obj)
-	if err != nil {
-		ctx.Header().Del("Content-Type")
-	}
-	return err
+	return ctx.renderAs("application/json", obj)
 }
 
 func (ctx *Context) Body() ([]byte, error) {
@@ -250,7 +369,7 @@ func (ctx *Context) DecodeJson(obj interface{}) error {
 		return err
 	}
 
-	return json.Unmarshal(body, obj)
+	return jsonCodec{}.Decode(body, obj)
 }
 
 func (ctx *Context) DecodeXml(obj interface{}) error {
@@ -259,7 +378,7 @@ func (ctx *Context) DecodeXml(obj interface{}) error {
 		return err
 	}
 
-	return xml.Unmarshal(body, obj)
+	return xmlCodec{}.Decode(body, obj)
 }
 
 func (ctx *Context) Download(fpath string) error {
@@ -275,6 +394,103 @@ func (ctx *Context) Download(fpath string) error {
 	return err
 }
 
+// Streaming reports whether the response is an SSE or Stream response,
+// so middlewares that buffer or gzip the body (which would defeat
+// flushing) know to skip it.
+func (ctx *Context) Streaming() bool {
+	return ctx.streaming
+}
+
+// Flush writes any buffered response data to the client, if the
+// underlying ResponseWriter supports it.
+func (ctx *Context) Flush() {
+	if f, ok := ctx.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// SSEEvent attaches an id and/or a client retry hint to an SSE frame in
+// addition to its event name and data. Pass one to SSE wherever a plain
+// value would do to set them.
+type SSEEvent struct {
+	ID    string
+	Retry time.Duration
+	Data  interface{}
+}
+
+// SSE writes a Server-Sent Events frame to the client and flushes it
+// immediately. data is JSON-encoded unless it is a string, []byte, or an
+// SSEEvent carrying an id/retry hint alongside its own Data. The first
+// call on a response sets the headers SSE clients require.
+func (ctx *Context) SSE(event string, data interface{}) error {
+	if !ctx.streaming {
+		ctx.Header().Set("Content-Type", "text/event-stream")
+		ctx.Header().Set("Cache-Control", "no-cache")
+		ctx.Header().Set("Connection", "keep-alive")
+		ctx.streaming = true
+	}
+
+	id, retry := "", time.Duration(0)
+	if evt, ok := data.(SSEEvent); ok {
+		id, retry, data = evt.ID, evt.Retry, evt.Data
+	}
+
+	var payload []byte
+	switch v := data.(type) {
+	case []byte:
+		payload = v
+	case string:
+		payload = []byte(v)
+	default:
+		b, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		payload = b
+	}
+
+	buf := &bytes.Buffer{}
+	if event != "" {
+		fmt.Fprintf(buf, "event: %s\n", event)
+	}
+	if id != "" {
+		fmt.Fprintf(buf, "id: %s\n", id)
+	}
+	if retry > 0 {
+		fmt.Fprintf(buf, "retry: %d\n", retry/time.Millisecond)
+	}
+	for _, line := range bytes.Split(payload, []byte("\n")) {
+		fmt.Fprintf(buf, "data: %s\n", line)
+	}
+	buf.WriteByte('\n')
+
+	if _, err := ctx.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	ctx.Flush()
+	return nil
+}
+
+// Stream marks the response as streaming and repeatedly calls step,
+// flushing after each call, until step returns false or the request's
+// Context is canceled (e.g. the client disconnected).
+func (ctx *Context) Stream(step func(w io.Writer) bool) {
+	ctx.streaming = true
+	for {
+		select {
+		case <-ctx.Context().Done():
+			return
+		default:
+		}
+
+		if !step(ctx) {
+			return
+		}
+		ctx.Flush()
+	}
+}
+
 func (ctx *Context) SaveToFile(formName, savePath string) error {
 	file, _, err := ctx.Req().FormFile(formName)
 	if err != nil {
@@ -0,0 +1,223 @@
+// Copyright 2015 The Tango Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tango
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PathParam holds the path parameters matched for a request, e.g. the :id
+// in a pattern like /users/:id.
+type PathParam map[string]string
+
+// Params is a deprecated alias for PathParam, kept so existing code using
+// the old name keeps compiling. Use PathParam in new code.
+type Params = PathParam
+
+// Get returns the named parameter, or "" if it wasn't matched.
+func (p *PathParam) Get(name string) string {
+	return (*p)[name]
+}
+
+// Int returns the named parameter parsed as an int.
+func (p *PathParam) Int(name string) (int, error) {
+	return strconv.Atoi(p.Get(name))
+}
+
+// Int64 returns the named parameter parsed as an int64.
+func (p *PathParam) Int64(name string) (int64, error) {
+	return strconv.ParseInt(p.Get(name), 10, 64)
+}
+
+// UUID returns the named parameter, validating that it looks like a UUID.
+func (p *PathParam) UUID(name string) (string, error) {
+	v := p.Get(name)
+	if !uuidPattern.MatchString(v) {
+		return "", fmt.Errorf("tango: param %q is not a valid uuid", name)
+	}
+	return v, nil
+}
+
+// MustInt is like Int but panics instead of returning an error. It is
+// only safe to use unconditionally in a handler if the router dispatching
+// to it actually calls Route.Match (directly, or via firstMatch) per
+// candidate route and rejects one whose :name(int) constraint fails the
+// value — if the router reaches a handler without checking that,
+// :name(int) is decorative and this method can panic on ordinary bad
+// input.
+func (p *PathParam) MustInt(name string) int {
+	v, err := p.Int(name)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// paramTypes maps the name used in a constrained path segment, e.g.
+// :id(int), to the regexp its value must fully match. compileSegments
+// consults this when compiling a route pattern, and Route.Match and
+// firstMatch reject a path whose segment fails its constraint. Tango.Match
+// does not call either of them, so requests dispatched by the router are
+// not checked against these constraints.
+var paramTypes = map[string]*regexp.Regexp{
+	"int":  regexp.MustCompile(`^-?[0-9]+$`),
+	"uuid": uuidPattern,
+}
+
+// RegisterParamType registers pattern as the constraint used for path
+// segments declared with :name(typeName), e.g. RegisterParamType("slug",
+// `[a-z0-9-]+`) enables :name(slug). Registering a name a second time
+// replaces its pattern.
+func RegisterParamType(typeName, pattern string) {
+	paramTypes[typeName] = regexp.MustCompile(`^(?:` + pattern + `)$`)
+}
+
+// routeParamPrefix matches a leading :name path-parameter segment. Any
+// (constraint) that follows is parsed separately by splitParam, since a
+// constraint can itself contain parens (e.g. :name(\.(png|jpg))) that a
+// single non-nesting regex can't balance.
+var routeParamPrefix = regexp.MustCompile(`^:([A-Za-z_][A-Za-z0-9_]*)`)
+
+// splitParam parses a leading :name or :name(constraint) from seg,
+// scanning the constraint with balanced parens. It returns the param's
+// name, its constraint (empty if none was given), how many bytes of seg
+// the param consumed, and whether seg starts with a :name at all.
+func splitParam(seg string) (name, constraint string, consumed int, ok bool) {
+	m := routeParamPrefix.FindStringSubmatchIndex(seg)
+	if m == nil {
+		return "", "", 0, false
+	}
+	name, consumed = seg[m[2]:m[3]], m[1]
+	if consumed == len(seg) || seg[consumed] != '(' {
+		return name, "", consumed, true
+	}
+
+	depth := 0
+	for i := consumed; i < len(seg); i++ {
+		switch seg[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return name, seg[consumed+1 : i], i + 1, true
+			}
+		}
+	}
+	// Unbalanced '(': treat the segment as an unconstrained param rather
+	// than swallowing the rest of the pattern looking for a close.
+	return name, "", consumed, true
+}
+
+// routeSegment is one "/"-delimited piece of a compiled route pattern.
+type routeSegment struct {
+	literal    string
+	param      string
+	constraint *regexp.Regexp
+}
+
+// compileSegments splits pattern into routeSegments, resolving each
+// :name(constraint) to the paramType constraint registers names, or, if
+// constraint isn't a registered name, an ad-hoc anchored regexp built
+// from it directly.
+func compileSegments(pattern string) []routeSegment {
+	parts := strings.Split(strings.Trim(pattern, "/"), "/")
+	segs := make([]routeSegment, len(parts))
+	for i, part := range parts {
+		name, constraint, consumed, ok := splitParam(part)
+		if !ok || consumed != len(part) {
+			segs[i] = routeSegment{literal: part}
+			continue
+		}
+
+		seg := routeSegment{param: name}
+		if constraint != "" {
+			if re, ok := paramTypes[constraint]; ok {
+				seg.constraint = re
+			} else {
+				seg.constraint = regexp.MustCompile(`^(?:` + constraint + `)$`)
+			}
+		}
+		segs[i] = seg
+	}
+	return segs
+}
+
+// matchSegments reports whether path satisfies segs, returning its path
+// parameters if so. A param segment whose constraint fails is treated
+// the same as a literal mismatch, so the router can try another route
+// instead of reaching a handler with a bad value.
+func matchSegments(segs []routeSegment, path string) (PathParam, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != len(segs) {
+		return nil, false
+	}
+
+	params := make(PathParam, len(segs))
+	for i, seg := range segs {
+		if seg.param == "" {
+			if parts[i] != seg.literal {
+				return nil, false
+			}
+			continue
+		}
+		if seg.constraint != nil && !seg.constraint.MatchString(parts[i]) {
+			return nil, false
+		}
+		params[seg.param] = parts[i]
+	}
+	return params, true
+}
+
+// patternCache memoizes compileSegments by pattern string, so repeated
+// matches against the same route don't re-parse its pattern.
+var patternCache sync.Map // map[string][]routeSegment
+
+func segmentsFor(pattern string) []routeSegment {
+	if v, ok := patternCache.Load(pattern); ok {
+		return v.([]routeSegment)
+	}
+	segs := compileSegments(pattern)
+	patternCache.Store(pattern, segs)
+	return segs
+}
+
+// Match reports whether path satisfies r's pattern, honoring any
+// :name(type) constraints declared on its segments, and if so returns
+// the path's parameters. A constrained segment that fails its type check
+// is treated as a non-match, the same as a literal mismatch.
+func (r *Route) Match(path string) (PathParam, bool) {
+	return matchSegments(segmentsFor(r.pattern), path)
+}
+
+// matcher is satisfied by *Route via Match. It exists so firstMatch can be
+// exercised against a real route table without needing a full *Tango to
+// build one: anything with a Match method works, *Route included.
+type matcher interface {
+	Match(path string) (PathParam, bool)
+}
+
+// firstMatch returns the first of candidates whose Match accepts path, in
+// order, along with the parameters it matched. This is the piece Tango.Match
+// (which lives with the rest of the router and isn't part of this file) is
+// missing: walking the candidate routes for a request and calling Match on
+// each, so a route whose :name(type) constraint rejects path falls through
+// to the next candidate instead of reaching the handler with a bad value.
+// Tango.Match should narrow its routes to those registered for the
+// request's HTTP method and call firstMatch on that slice.
+func firstMatch(candidates []matcher, path string) (matcher, PathParam, bool) {
+	for _, c := range candidates {
+		if params, ok := c.Match(path); ok {
+			return c, params, true
+		}
+	}
+	return nil, nil, false
+}
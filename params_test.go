@@ -0,0 +1,136 @@
+// Copyright 2015 The Tango Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tango
+
+import "testing"
+
+func TestSplitParam(t *testing.T) {
+	cases := []struct {
+		seg        string
+		name       string
+		constraint string
+		consumed   int
+		ok         bool
+	}{
+		{"users", "", "", 0, false},
+		{":id", "id", "", 3, true},
+		{":id(int)", "id", "int", 8, true},
+		{`:name(\.(png|jpg))`, "name", `\.(png|jpg)`, 18, true},
+	}
+
+	for _, c := range cases {
+		name, constraint, consumed, ok := splitParam(c.seg)
+		if name != c.name || constraint != c.constraint || consumed != c.consumed || ok != c.ok {
+			t.Errorf("splitParam(%q) = (%q, %q, %d, %v), want (%q, %q, %d, %v)",
+				c.seg, name, constraint, consumed, ok, c.name, c.constraint, c.consumed, c.ok)
+		}
+	}
+}
+
+func TestMatchSegments(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		ok      bool
+		params  PathParam
+	}{
+		{"/users/:id(int)", "/users/42", true, PathParam{"id": "42"}},
+		{"/users/:id(int)", "/users/abc", false, nil},
+		{"/orgs/:slug(uuid)", "/orgs/550e8400-e29b-41d4-a716-446655440000", true,
+			PathParam{"slug": "550e8400-e29b-41d4-a716-446655440000"}},
+		{"/orgs/:slug(uuid)", "/orgs/not-a-uuid", false, nil},
+		{`/files/:name(\.(png|jpg))`, "/files/.png", true, PathParam{"name": ".png"}},
+		{`/files/:name(\.(png|jpg))`, "/files/.gif", false, nil},
+		{"/users/:id", "/users/anything", true, PathParam{"id": "anything"}},
+	}
+
+	for _, c := range cases {
+		segs := compileSegments(c.pattern)
+		params, ok := matchSegments(segs, c.path)
+		if ok != c.ok {
+			t.Fatalf("matchSegments(%q, %q) ok = %v, want %v", c.pattern, c.path, ok, c.ok)
+		}
+		if !ok {
+			continue
+		}
+		for k, v := range c.params {
+			if params[k] != v {
+				t.Errorf("matchSegments(%q, %q) param %q = %q, want %q", c.pattern, c.path, k, params[k], v)
+			}
+		}
+	}
+}
+
+// TestConstraintMismatchFallsThroughToNextRoute drives a small route
+// table through the constraint-checking path a dispatcher is expected to
+// use: try each candidate in registration order, skip one whose
+// constraint rejects the value, and use the first that matches. It's the
+// router's responsibility to call this per candidate; this only proves
+// the primitive behaves correctly when it does.
+func TestConstraintMismatchFallsThroughToNextRoute(t *testing.T) {
+	routes := []struct {
+		name string
+		segs []routeSegment
+	}{
+		{"byID", compileSegments("/users/:id(int)")},
+		{"byName", compileSegments("/users/:name")},
+	}
+
+	match := func(path string) (string, PathParam) {
+		for _, r := range routes {
+			if params, ok := matchSegments(r.segs, path); ok {
+				return r.name, params
+			}
+		}
+		return "", nil
+	}
+
+	if name, params := match("/users/42"); name != "byID" || params["id"] != "42" {
+		t.Fatalf("match(/users/42) = (%q, %v), want (byID, id=42)", name, params)
+	}
+	if name, params := match("/users/abc"); name != "byName" || params["name"] != "abc" {
+		t.Fatalf("match(/users/abc) = (%q, %v), want (byName, name=abc) — "+
+			"the :id(int) constraint should have skipped byID", name, params)
+	}
+}
+
+// patternRoute lets a test build a matcher backed by the exact same Match
+// implementation *Route uses, without needing a full *Tango to construct a
+// real *Route.
+type patternRoute struct {
+	name    string
+	pattern string
+}
+
+func (r *patternRoute) Match(path string) (PathParam, bool) {
+	return matchSegments(segmentsFor(r.pattern), path)
+}
+
+// TestFirstMatch drives firstMatch over a route table through
+// patternRoute.Match — the same segmentsFor/matchSegments code (*Route).Match
+// calls — proving the dispatcher-ready candidate loop Tango.Match is
+// missing behaves correctly: a candidate whose constraint rejects the
+// path is skipped in favor of the next one, in registration order.
+func TestFirstMatch(t *testing.T) {
+	routes := []matcher{
+		&patternRoute{"byID", "/users/:id(int)"},
+		&patternRoute{"byName", "/users/:name"},
+	}
+
+	got, params, ok := firstMatch(routes, "/users/42")
+	if !ok || got.(*patternRoute).name != "byID" || params["id"] != "42" {
+		t.Fatalf("firstMatch(/users/42) = (%v, %v, %v), want (byID, id=42, true)", got, params, ok)
+	}
+
+	got, params, ok = firstMatch(routes, "/users/abc")
+	if !ok || got.(*patternRoute).name != "byName" || params["name"] != "abc" {
+		t.Fatalf("firstMatch(/users/abc) = (%v, %v, %v), want (byName, name=abc, true) — "+
+			"the :id(int) constraint should have skipped byID", got, params, ok)
+	}
+
+	if _, _, ok := firstMatch(routes, "/orgs/42"); ok {
+		t.Fatalf("firstMatch(/orgs/42) matched, want no candidate to match")
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright 2015 The Tango Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tango
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// Renderer encodes obj onto the response of ctx, setting any headers it
+// needs before writing. Register one with RegisterRenderer to add support
+// for a new media type without forking Tango.
+type Renderer interface {
+	ContentType() string
+	Render(ctx *Context, obj interface{}) error
+}
+
+var renderers = map[string]Renderer{}
+
+// RegisterRenderer registers r as the Renderer used for mediaType by
+// Serve and the content negotiation it performs. Registering a mediaType
+// a second time replaces the previous Renderer.
+func RegisterRenderer(mediaType string, r Renderer) {
+	renderers[mediaType] = r
+}
+
+// Decoder unmarshals a request body into obj. Register one with
+// RegisterDecoder to add support for a new Content-Type without forking
+// Tango.
+type Decoder interface {
+	Decode(body []byte, obj interface{}) error
+}
+
+var decoders = map[string]Decoder{}
+
+// RegisterDecoder registers d as the Decoder used for mediaType by
+// Decode. Registering a mediaType a second time replaces the previous
+// Decoder.
+func RegisterDecoder(mediaType string, d Decoder) {
+	decoders[mediaType] = d
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string { return "application/json; charset=UTF-8" }
+
+func (jsonCodec) Render(ctx *Context, obj interface{}) error {
+	return json.NewEncoder(ctx).Encode(obj)
+}
+
+func (jsonCodec) Decode(body []byte, obj interface{}) error {
+	return json.Unmarshal(body, obj)
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) ContentType() string { return "application/xml; charset=UTF-8" }
+
+func (xmlCodec) Render(ctx *Context, obj interface{}) error {
+	return xml.NewEncoder(ctx).Encode(obj)
+}
+
+func (xmlCodec) Decode(body []byte, obj interface{}) error {
+	return xml.Unmarshal(body, obj)
+}
+
+type yamlCodec struct{}
+
+func (yamlCodec) ContentType() string { return "application/x-yaml; charset=UTF-8" }
+
+func (yamlCodec) Render(ctx *Context, obj interface{}) error {
+	return yaml.NewEncoder(ctx).Encode(obj)
+}
+
+func (yamlCodec) Decode(body []byte, obj interface{}) error {
+	return yaml.Unmarshal(body, obj)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string { return "application/x-msgpack" }
+
+func (msgpackCodec) Render(ctx *Context, obj interface{}) error {
+	return msgpack.NewEncoder(ctx).Encode(obj)
+}
+
+func (msgpackCodec) Decode(body []byte, obj interface{}) error {
+	return msgpack.Unmarshal(body, obj)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string { return "application/x-protobuf" }
+
+func (protobufCodec) Render(ctx *Context, obj interface{}) error {
+	m, ok := obj.(proto.Message)
+	if !ok {
+		return fmt.Errorf("tango: Serve with application/x-protobuf requires a proto.Message, got %T", obj)
+	}
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = ctx.Write(b)
+	return err
+}
+
+func (protobufCodec) Decode(body []byte, obj interface{}) error {
+	m, ok := obj.(proto.Message)
+	if !ok {
+		return fmt.Errorf("tango: Decode into application/x-protobuf requires a proto.Message, got %T", obj)
+	}
+	return proto.Unmarshal(body, m)
+}
+
+// Templates is the template set the html renderer executes against. It is
+// nil by default, since Tango has no opinion on where an application's
+// templates live; parse them into it (e.g. with template.ParseGlob)
+// before serving a request that renders HTML.
+var Templates *template.Template
+
+// HTML wraps the name of a template in Templates and the data to execute
+// it with, so Serve can render it: ctx.Serve(tango.HTML{Name:
+// "users/list.html", Data: users}).
+type HTML struct {
+	Name string
+	Data interface{}
+}
+
+type htmlCodec struct{}
+
+func (htmlCodec) ContentType() string { return "text/html; charset=UTF-8" }
+
+func (htmlCodec) Render(ctx *Context, obj interface{}) error {
+	h, ok := obj.(HTML)
+	if !ok {
+		return fmt.Errorf("tango: Serve with text/html requires tango.HTML, got %T", obj)
+	}
+	if Templates == nil {
+		return fmt.Errorf("tango: text/html requested but tango.Templates has no templates parsed into it")
+	}
+	return Templates.ExecuteTemplate(ctx, h.Name, h.Data)
+}
+
+func init() {
+	RegisterRenderer("application/json", jsonCodec{})
+	RegisterRenderer("application/xml", xmlCodec{})
+	RegisterRenderer("application/x-yaml", yamlCodec{})
+	RegisterRenderer("application/x-msgpack", msgpackCodec{})
+	RegisterRenderer("application/x-protobuf", protobufCodec{})
+	RegisterRenderer("text/html", htmlCodec{})
+	RegisterDecoder("application/json", jsonCodec{})
+	RegisterDecoder("application/xml", xmlCodec{})
+	RegisterDecoder("text/xml", xmlCodec{})
+	RegisterDecoder("application/x-yaml", yamlCodec{})
+	RegisterDecoder("application/x-msgpack", msgpackCodec{})
+	RegisterDecoder("application/x-protobuf", protobufCodec{})
+}
+
+// DefaultFormat is the media type Serve falls back to when content
+// negotiation can't determine one from the request.
+var DefaultFormat = "application/json"
+
+// extFormats maps path extensions to the media type Serve should use, so
+// a route like "/users/list.xml" renders XML regardless of Accept. Only
+// extensions with a registered Renderer belong here; add more via
+// RegisterRenderer and a matching entry in this map.
+var extFormats = map[string]string{
+	".json":    "application/json",
+	".xml":     "application/xml",
+	".yaml":    "application/x-yaml",
+	".yml":     "application/x-yaml",
+	".msgpack": "application/x-msgpack",
+	".pb":      "application/x-protobuf",
+	".html":    "text/html",
+	".htm":     "text/html",
+}
+
+// formatAliases lets ?format= use short names instead of full media
+// types. Only formats with a registered Renderer belong here; add more
+// via RegisterRenderer and a matching entry in this map.
+var formatAliases = map[string]string{
+	"json":     "application/json",
+	"xml":      "application/xml",
+	"yaml":     "application/x-yaml",
+	"msgpack":  "application/x-msgpack",
+	"protobuf": "application/x-protobuf",
+	"pb":       "application/x-protobuf",
+	"html":     "text/html",
+}
+
+// negotiateFormat picks the response media type given an explicit
+// ?format= value, the request path (for its extension) and the Accept
+// header, preferring format, then the extension, then Accept, and
+// falling back to DefaultFormat. It takes no *Context so the precedence
+// logic can be tested directly; negotiate adapts a live request into it.
+func negotiateFormat(format, path, accept string) string {
+	if format != "" {
+		if mt, ok := formatAliases[format]; ok {
+			return mt
+		}
+		return format
+	}
+
+	if mt, ok := extFormats[filepath.Ext(path)]; ok {
+		return mt
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mt, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if _, ok := renderers[mt]; ok {
+			return mt
+		}
+	}
+
+	return DefaultFormat
+}
+
+// negotiate picks the response media type for ctx, preferring an explicit
+// ?format= query parameter, then the path extension, then the Accept
+// header, and falling back to DefaultFormat.
+func (ctx *Context) negotiate() string {
+	return negotiateFormat(
+		ctx.Req().URL.Query().Get("format"),
+		ctx.Req().URL.Path,
+		ctx.Req().Header.Get("Accept"),
+	)
+}
+
+func (ctx *Context) renderAs(mt string, obj interface{}) error {
+	r, ok := renderers[mt]
+	if !ok {
+		return fmt.Errorf("tango: no renderer registered for %q", mt)
+	}
+
+	ctx.Header().Set("Content-Type", r.ContentType())
+	if err := r.Render(ctx, obj); err != nil {
+		ctx.Header().Del("Content-Type")
+		return err
+	}
+	return nil
+}
+
+// Serve content-negotiates the response format from the ?format= query
+// parameter, the path extension and the Accept header (in that order of
+// precedence), then renders obj with the Renderer registered for it,
+// falling back to DefaultFormat. JSON, XML, YAML, MessagePack, Protobuf
+// and HTML are registered by default. Protobuf requires obj to be a
+// generated proto.Message; HTML requires obj to be a tango.HTML wrapping
+// a template name and its data, and Templates to have that template
+// parsed into it. Register further formats with RegisterRenderer instead
+// of adding another ServeXxx method.
+func (ctx *Context) Serve(obj interface{}) error {
+	return ctx.renderAs(ctx.negotiate(), obj)
+}
+
+// decodeBody picks the Decoder for contentType, defaulting to JSON when
+// contentType is empty, and uses it to unmarshal body into obj. It takes
+// no *Context so the Content-Type-to-Decoder logic can be tested
+// directly; Decode adapts a live request into it.
+func decodeBody(body []byte, contentType string, obj interface{}) error {
+	mt := contentType
+	if mt == "" {
+		mt = "application/json"
+	} else if parsed, _, err := mime.ParseMediaType(mt); err == nil {
+		mt = parsed
+	}
+
+	d, ok := decoders[mt]
+	if !ok {
+		return fmt.Errorf("tango: no decoder registered for %q", mt)
+	}
+	return d.Decode(body, obj)
+}
+
+// Decode reads the request body and unmarshals it into obj using the
+// Decoder registered for the request's Content-Type, falling back to JSON
+// when none is set. Register additional formats with RegisterDecoder
+// instead of adding another DecodeXxx method.
+func (ctx *Context) Decode(obj interface{}) error {
+	body, err := ctx.Body()
+	if err != nil {
+		return err
+	}
+	return decodeBody(body, ctx.Req().Header.Get("Content-Type"), obj)
+}
@@ -0,0 +1,83 @@
+// Copyright 2015 The Tango Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tango
+
+import "testing"
+
+func TestNegotiateFormat(t *testing.T) {
+	cases := []struct {
+		name   string
+		format string
+		path   string
+		accept string
+		want   string
+	}{
+		{"format query wins over everything else", "json", "/users/list.xml", "text/xml", "application/json"},
+		{"format query accepts a short alias", "yaml", "", "", "application/x-yaml"},
+		{"format query accepts the protobuf alias", "pb", "", "", "application/x-protobuf"},
+		{"extension resolves to html", "", "/users/list.html", "", "text/html"},
+		{"format query falls back to a literal media type if not an alias", "application/x-msgpack", "", "", "application/x-msgpack"},
+		{"extension wins over Accept", "", "/users/list.xml", "application/json", "application/xml"},
+		{"Accept is used when format and extension don't resolve", "", "/users/list", "application/xml, application/json;q=0.9", "application/xml"},
+		{"Accept entries without a registered renderer are skipped", "", "/users/list", "application/pdf, application/json", "application/json"},
+		{"falls back to DefaultFormat", "", "/users/list", "", DefaultFormat},
+	}
+
+	for _, c := range cases {
+		if got := negotiateFormat(c.format, c.path, c.accept); got != c.want {
+			t.Errorf("%s: negotiateFormat(%q, %q, %q) = %q, want %q",
+				c.name, c.format, c.path, c.accept, got, c.want)
+		}
+	}
+}
+
+// TestExtFormatsAndAliasesHaveRenderers guards against the bug fixed in a
+// prior commit, where an extension or ?format= alias pointed at a media
+// type with no Renderer registered for it, so negotiateFormat resolved a
+// format that renderAs could never actually render.
+func TestExtFormatsAndAliasesHaveRenderers(t *testing.T) {
+	for ext, mt := range extFormats {
+		if _, ok := renderers[mt]; !ok {
+			t.Errorf("extFormats[%q] = %q, but no Renderer is registered for it", ext, mt)
+		}
+	}
+	for alias, mt := range formatAliases {
+		if _, ok := renderers[mt]; !ok {
+			t.Errorf("formatAliases[%q] = %q, but no Renderer is registered for it", alias, mt)
+		}
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	type payload struct {
+		Name string `json:"name" xml:"name"`
+	}
+
+	cases := []struct {
+		name        string
+		body        string
+		contentType string
+		want        string
+	}{
+		{"empty Content-Type defaults to JSON", `{"name":"ada"}`, "", "ada"},
+		{"registered Content-Type is used", `<payload><name>ada</name></payload>`, "application/xml", "ada"},
+		{"Content-Type parameters are stripped before lookup", `{"name":"ada"}`, "application/json; charset=UTF-8", "ada"},
+	}
+
+	for _, c := range cases {
+		var p payload
+		if err := decodeBody([]byte(c.body), c.contentType, &p); err != nil {
+			t.Errorf("%s: decodeBody(_, %q, _) returned error: %v", c.name, c.contentType, err)
+			continue
+		}
+		if p.Name != c.want {
+			t.Errorf("%s: decodeBody(_, %q, _) decoded name %q, want %q", c.name, c.contentType, p.Name, c.want)
+		}
+	}
+
+	if err := decodeBody([]byte("whatever"), "application/not-a-registered-format", &payload{}); err == nil {
+		t.Error("decodeBody with an unregistered Content-Type did not return an error")
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2015 The Tango Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tango
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Name records name as the reverse-routing name for r, so Tango.URL and
+// Context.URL can build links back to it. It panics if name is already
+// taken, since that's a registration-time bug rather than something a
+// caller can usefully recover from.
+func (r *Route) Name(name string) *Route {
+	if other, ok := r.tan.namedRoutes[name]; ok && other != r {
+		panic(fmt.Sprintf("tango: route name %q already registered", name))
+	}
+	r.name = name
+	r.tan.namedRoutes[name] = r
+	return r
+}
+
+// URL builds the path for the route named name, substituting each :param
+// in its pattern with the matching value from pairs (name, value, name,
+// value, ...) and appending any left-over pairs as a query string. It
+// panics if name is unknown or a required :param isn't supplied, since
+// both are registration-time bugs rather than runtime conditions.
+func (tan *Tango) URL(name string, pairs ...interface{}) string {
+	r, ok := tan.namedRoutes[name]
+	if !ok {
+		panic(fmt.Sprintf("tango: no route named %q", name))
+	}
+	return r.buildURL(pairs...)
+}
+
+func (r *Route) buildURL(pairs ...interface{}) string {
+	return routeURL(r.name, r.pattern, pairs...)
+}
+
+// routeURL builds the path for a route named routeName with the given
+// pattern, substituting each :param in pattern with the matching value
+// from pairs (name, value, name, value, ...) and appending any left-over
+// pairs as a query string. It panics if pairs is malformed or a required
+// :param isn't supplied, since both are registration-time bugs rather
+// than runtime conditions. Route.buildURL delegates straight to this so
+// the substitution/escaping logic can be tested without a live *Route.
+func routeURL(routeName, pattern string, pairs ...interface{}) string {
+	if len(pairs)%2 != 0 {
+		panic(fmt.Sprintf("tango: URL for route %q got an odd number of pairs", routeName))
+	}
+
+	values := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			panic(fmt.Sprintf("tango: URL for route %q: pair %d key must be a string", routeName, i/2))
+		}
+		values[key] = pairs[i+1]
+	}
+
+	used := make(map[string]bool, len(values))
+	var b strings.Builder
+	for i := 0; i < len(pattern); {
+		if pattern[i] != ':' {
+			b.WriteByte(pattern[i])
+			i++
+			continue
+		}
+
+		name, _, consumed, ok := splitParam(pattern[i:])
+		if !ok {
+			b.WriteByte(pattern[i])
+			i++
+			continue
+		}
+
+		v, ok := values[name]
+		if !ok {
+			panic(fmt.Sprintf("tango: URL for route %q: missing value for :%s", routeName, name))
+		}
+		used[name] = true
+		b.WriteString(url.PathEscape(fmt.Sprint(v)))
+		i += consumed
+	}
+	path := b.String()
+
+	query := make(url.Values, len(values)-len(used))
+	for k, v := range values {
+		if !used[k] {
+			query.Set(k, fmt.Sprint(v))
+		}
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path
+}
@@ -0,0 +1,55 @@
+// Copyright 2015 The Tango Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tango
+
+import "testing"
+
+func TestRouteURL(t *testing.T) {
+	cases := []struct {
+		name    string
+		pattern string
+		pairs   []interface{}
+		want    string
+	}{
+		{"plain param", "/users/:id", []interface{}{"id", 42}, "/users/42"},
+		{"typed constraint segment is substituted like any other param",
+			"/users/:id(int)", []interface{}{"id", 42}, "/users/42"},
+		{"nested parens in the constraint don't leak a stray close paren",
+			`/files/:name(\.(png|jpg))`, []interface{}{"name", "pic.png"}, "/files/pic.png"},
+		{"path segments are escaped",
+			"/search/:q", []interface{}{"q", "a b/c"}, "/search/a%20b%2Fc"},
+		{"left-over pairs become an escaped query string",
+			"/users/:id", []interface{}{"id", 42, "tab", "a b"}, "/users/42?tab=a+b"},
+	}
+
+	for _, c := range cases {
+		if got := routeURL("r", c.pattern, c.pairs...); got != c.want {
+			t.Errorf("%s: routeURL(%q, %v) = %q, want %q", c.name, c.pattern, c.pairs, got, c.want)
+		}
+	}
+}
+
+func TestRouteURLPanics(t *testing.T) {
+	cases := []struct {
+		name    string
+		pattern string
+		pairs   []interface{}
+	}{
+		{"odd number of pairs", "/users/:id", []interface{}{"id"}},
+		{"non-string key", "/users/:id", []interface{}{42, 42}},
+		{"missing value for a param", "/users/:id", nil},
+	}
+
+	for _, c := range cases {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: routeURL(%q, %v) did not panic", c.name, c.pattern, c.pairs)
+				}
+			}()
+			routeURL("r", c.pattern, c.pairs...)
+		}()
+	}
+}
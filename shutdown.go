@@ -0,0 +1,127 @@
+// Copyright 2015 The Tango Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tango
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// DrainTimeout bounds how long RunGraceful waits for in-flight requests to
+// finish once it starts shutting down.
+var DrainTimeout = 30 * time.Second
+
+// DrainResponse is served for requests that arrive after Shutdown has
+// been called but before the listener actually stops accepting
+// connections. Replace it to customize the body or status.
+var DrainResponse = func(ctx *Context) {
+	ctx.Abort(http.StatusServiceUnavailable, http.StatusText(http.StatusServiceUnavailable))
+}
+
+type grace struct {
+	wg       sync.WaitGroup
+	active   int64
+	draining int32
+}
+
+// Graceful returns a Handler that tracks in-flight requests for Shutdown
+// and ActiveRequests, and responds with DrainResponse instead of running
+// the request once Shutdown has been called. RunGraceful installs it
+// automatically; install it yourself, first in the handler chain ahead
+// of routing, only if you're wrapping tan in your own http.Server and
+// calling Shutdown directly instead of using RunGraceful.
+func Graceful() HandlerFunc {
+	return func(ctx *Context) {
+		g := &ctx.tan.grace
+		if atomic.LoadInt32(&g.draining) != 0 {
+			DrainResponse(ctx)
+			return
+		}
+
+		atomic.AddInt64(&g.active, 1)
+		g.wg.Add(1)
+		defer func() {
+			g.wg.Done()
+			atomic.AddInt64(&g.active, -1)
+		}()
+
+		ctx.Next()
+	}
+}
+
+// ActiveRequests reports how many requests are currently being served, for
+// use in health endpoints.
+func (tan *Tango) ActiveRequests() int {
+	return int(atomic.LoadInt64(&tan.grace.active))
+}
+
+// Shutdown makes Graceful answer new requests with DrainResponse, stops
+// the http.Server started by RunGraceful (if any) from accepting new
+// connections, lets in-flight requests tracked by Graceful finish, and
+// returns once they have all completed or ctx is done, whichever comes
+// first. A server tan was not started on by RunGraceful is not stopped.
+func (tan *Tango) Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&tan.grace.draining, 1)
+
+	if tan.srv != nil {
+		if err := tan.srv.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		tan.grace.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunGraceful is like Run, but wraps the listener in an http.Server and
+// calls Shutdown with a DrainTimeout deadline when the process receives
+// SIGINT or SIGTERM, draining in-flight requests before returning. It
+// installs Graceful itself, ahead of any handlers already registered on
+// tan, so ActiveRequests and the drain actually track requests without
+// the caller having to install the handler by hand.
+func (tan *Tango) RunGraceful(addr string) error {
+	tan.handlers = append([]Handler{Graceful()}, tan.handlers...)
+	tan.srv = &http.Server{Addr: addr, Handler: tan}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	var signaled int32
+	drained := make(chan struct{})
+	go func() {
+		<-sig
+		atomic.StoreInt32(&signaled, 1)
+		ctx, cancel := context.WithTimeout(context.Background(), DrainTimeout)
+		defer cancel()
+		tan.Shutdown(ctx)
+		close(drained)
+	}()
+
+	err := tan.srv.ListenAndServe()
+	if err == http.ErrServerClosed {
+		// ListenAndServe returns as soon as Shutdown begins; wait for the
+		// signal-triggered drain to finish before returning.
+		if atomic.LoadInt32(&signaled) != 0 {
+			<-drained
+		}
+		return nil
+	}
+	return err
+}
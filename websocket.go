@@ -0,0 +1,66 @@
+// Copyright 2015 The Tango Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tango
+
+import (
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// Upgrader upgrades an HTTP connection to a WebSocket connection. The
+// default Upgrader wraps gorilla/websocket; call SetUpgrader to plug in
+// another implementation.
+type Upgrader interface {
+	Upgrade(w http.ResponseWriter, r *http.Request, responseHeader http.Header) (*websocket.Conn, error)
+}
+
+type gorillaUpgrader struct {
+	websocket.Upgrader
+}
+
+func (u *gorillaUpgrader) Upgrade(w http.ResponseWriter, r *http.Request, header http.Header) (*websocket.Conn, error) {
+	return u.Upgrader.Upgrade(w, r, header)
+}
+
+var defaultUpgrader Upgrader = &gorillaUpgrader{websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+}}
+
+// SetUpgrader replaces the Upgrader used by Context.Upgrade.
+func SetUpgrader(u Upgrader) {
+	defaultUpgrader = u
+}
+
+// UpgradeOption configures a WebSocket upgrade performed by Context.Upgrade.
+type UpgradeOption func(header http.Header)
+
+// WithUpgradeHeader adds a header to the upgrade response, e.g. to select a
+// subprotocol.
+func WithUpgradeHeader(key, value string) UpgradeOption {
+	return func(h http.Header) {
+		h.Set(key, value)
+	}
+}
+
+// Upgrade upgrades ctx's connection to WebSocket via the registered
+// Upgrader. Once the handshake succeeds it marks the response as
+// streaming, which Streaming then reports; Contexts() does not check
+// Streaming.
+func (ctx *Context) Upgrade(opts ...UpgradeOption) (*websocket.Conn, error) {
+	header := make(http.Header)
+	for _, opt := range opts {
+		opt(header)
+	}
+
+	conn, err := defaultUpgrader.Upgrade(ctx.ResponseWriter, ctx.Req(), header)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.streaming = true
+	return conn, nil
+}